@@ -5,34 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"os"
-	"os/signal"
 	"strconv"
-	"syscall"
+	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
-	"github.com/joho/godotenv"
-)
-
-const (
-	Endpoint        = "https://practicum.yandex.ru/api/user_api/homework_statuses/"
-	RetryPeriod     = 100 * time.Second
-	ApprovedStatus  = "approved"
-	ReviewingStatus = "reviewing"
-	RejectedStatus  = "rejected"
-)
-
-var (
-	PracticumToken  string
-	TelegramToken   string
-	TelegramChatID  string
-	lastSentMessage string
-	HomeworkVerdict map[string]string
-	logger          *log.Logger
-	bot             *tgbotapi.BotAPI
 )
 
 type Homework struct {
@@ -42,81 +20,56 @@ type Homework struct {
 	LessonName      string `json:"lesson_name"`
 }
 
-func init() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Ошибка при загрузке файла .env")
-	}
-
-	PracticumToken = os.Getenv("PRACTICUM_TOKEN")
-	TelegramToken = os.Getenv("TELEGRAM_TOKEN")
-	TelegramChatID = os.Getenv("TELEGRAM_CHAT_ID")
-
-	if PracticumToken == "" || TelegramToken == "" || TelegramChatID == "" {
-		log.Fatal("Отсутствуют переменные окружения")
-	}
-
-	HomeworkVerdict = map[string]string{
-		ApprovedStatus:  "Работа проверена: ревьюеру всё понравилось. Ура!",
-		ReviewingStatus: "Работа взята на проверку ревьюером.",
-		RejectedStatus:  "Работа проверена: у ревьюера есть замечания.",
-	}
-
-	logFile, err := os.OpenFile("logs/bot.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatal("Ошибка при открытии файла журнала:", err)
-	}
-
-	logger = log.New(io.MultiWriter(os.Stdout, logFile), "BOT: ", log.Ldate|log.Ltime|log.Lshortfile)
-	logger.Println("Бот запущен")
-
-	bot, err = tgbotapi.NewBotAPI(TelegramToken)
-	if err != nil {
-		log.Fatal("Ошибка при создании экземпляра бота:", err)
-	}
-	logger.Printf("Авторизован как @%s", bot.Self.UserName)
-}
-
 func sendMessage(chatID int64, message string) error {
 	msg := tgbotapi.NewMessage(chatID, message)
 	_, err := bot.Send(msg)
+	if err != nil {
+		telegramSendFailuresTotal.Inc()
+	}
 	return err
 }
 
-func getAPIAnswer(currentTimestamp int64) (map[string]interface{}, error) {
-	timestamp := currentTimestamp - 3600
-	if currentTimestamp == 0 {
-		timestamp = time.Now().Unix()
-	}
+// getAPIAnswer запрашивает у API статусы домашних работ пользователя chatID с
+// данным токеном Практикума, обновлённые начиная с fromDate.
+func getAPIAnswer(chatID int64, token string, fromDate int64) (map[string]interface{}, error) {
+	start := time.Now()
+	defer func() { apiRequestDuration.Observe(time.Since(start).Seconds()) }()
 
-	url := fmt.Sprintf("%s?from_date=%d", Endpoint, timestamp)
+	url := fmt.Sprintf("%s?from_date=%d", Endpoint, fromDate)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", PracticumToken))
+	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", token))
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("Ошибка при выполнении запроса к API: %v", err)
+		logger.APIError(chatID, 0, err)
+		apiRequestsTotal.WithLabelValues("error").Inc()
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			logger.APIError(chatID, 0, fmt.Errorf("закрытие тела ответа: %w", err))
+		}
+	}(resp.Body)
+
+	apiRequestsTotal.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Запрос к API завершился с кодом статуса: %d", resp.StatusCode)
-		return nil, fmt.Errorf("запрос к API завершился с кодом статуса: %d", resp.StatusCode)
+		return nil, &apiStatusError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
 
 	var data map[string]interface{}
 	err = json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
-		log.Printf("Ошибка при декодировании ответа от API: %v", err)
+		logger.APIError(chatID, resp.StatusCode, err)
 		return nil, err
 	}
 
-	log.Println("Успешно получен ответ от API")
+	logger.APIRequest(chatID, resp.StatusCode, time.Since(start))
 	return data, nil
 }
 
@@ -172,152 +125,132 @@ func parseStatus(homework Homework) (string, error) {
 		return message, nil
 	default:
 		errMsg := fmt.Sprintf("Неизвестный статус домашней работы: %s", homeworkStatus)
-		logger.Printf("Ошибка при разборе статуса домашней работы: %s", errMsg)
+		logger.Error("неизвестный статус домашней работы", "event", "parse_error", "homework_name", homeworkName, "status", homeworkStatus)
 		return "", errors.New(errMsg)
 	}
 }
 
-func handleCommand(msg *tgbotapi.Message) {
-	switch msg.Command() {
-	case "start":
-		sendMessage(msg.Chat.ID, "Привет! Я бот, который отслеживает статус проверки домашних работ.")
-		logger.Printf("Получена команда /start от пользователя с ID %d\n", msg.From.ID)
-	case "status":
-		go func() {
-			currentTimestamp := time.Now().Unix()
-			response, err := getAPIAnswer(currentTimestamp)
-			if err != nil {
-				logger.Printf("Не удалось получить ответ от API: %v", err)
-				sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
-				return
-			}
-
-			currentTimestamp = int64(response["current_date"].(float64))
-			newHomeworks, err := checkResponse(response)
-			if err != nil {
-				logger.Printf("Неверный ответ от API: %v", err)
-				sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
-				return
-			}
-
-			if len(newHomeworks) > 0 {
-				currentReport := newHomeworks[0]
-				message, err := parseStatus(currentReport)
-				if err != nil {
-					sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
-					return
-				}
-
-				sendMessage(msg.Chat.ID, message)
-				logger.Printf("Получена команда /status от пользователя с ID %d\n", msg.From.ID)
-				logger.Printf("Результат запроса к API: %s\n", message)
-			} else {
-				sendMessage(msg.Chat.ID, "Нет новых статусов работ.")
-				logger.Printf("Получена команда /status от пользователя с ID %d\n", msg.From.ID)
-				logger.Println("Результат запроса к API: Нет новых статусов работ.")
-			}
-		}()
-	}
-}
-
 func handleUpdates(updates tgbotapi.UpdatesChannel) {
 	for update := range updates {
-		if update.Message != nil {
-			if update.Message.IsCommand() {
-				handleCommand(update.Message)
-			}
+		switch {
+		case update.Message != nil && update.Message.IsCommand():
+			commandRouter.Dispatch(update.Message)
+		case update.CallbackQuery != nil:
+			handleCallbackQuery(update.CallbackQuery)
 		}
 	}
 }
 
-func fetchAPIResponse() ([]Homework, error) {
-	currentTimestamp := time.Now().Unix()
-	response, err := getAPIAnswer(currentTimestamp)
+// fetchAPIResponse опрашивает API от имени пользователя user начиная с его
+// сохранённого курсора и рассылает уведомления только по тем домашним работам,
+// чей статус действительно изменился с прошлого опроса.
+func fetchAPIResponse(user User) ([]Homework, error) {
+	fromDate, err := store.Cursor(user.ChatID)
+	if err != nil {
+		logger.StoreError(user.ChatID, "cursor_read_error", err)
+		return nil, err
+	}
+	if fromDate == 0 {
+		fromDate = time.Now().Unix() - 3600
+	}
+
+	response, err := getAPIAnswerWithRetry(user.ChatID, user.Token, fromDate)
 	if err != nil {
-		logger.Printf("Не удалось получить ответ от API: %v", err)
+		if !errors.Is(err, ErrCircuitOpen) {
+			logger.APIError(user.ChatID, 0, err)
+		}
 		return nil, err
 	}
 
-	currentTimestamp = int64(response["current_date"].(float64))
 	newHomeworks, err := checkResponse(response)
 	if err != nil {
-		logger.Printf("Неверный ответ от API: %v", err)
+		logger.APIError(user.ChatID, 0, err)
 		return nil, err
 	}
 
-	if len(newHomeworks) > 0 {
-		currentReport := newHomeworks[0]
-		message, err := parseStatus(currentReport)
-		if err != nil {
-			return nil, err
-		}
+	muted, err := store.IsMuted(user.ChatID)
+	if err != nil {
+		logger.StoreError(user.ChatID, "mute_read_error", err)
+	}
 
-		// Проверка, было ли отправлено сообщение с таким же статусом ранее
-		if message != lastSentMessage {
-			// Отправить сообщение в Telegram
-			chatID, err := strconv.ParseInt(TelegramChatID, 10, 64)
+	if len(newHomeworks) > 0 {
+		for _, hw := range newHomeworks {
+			lastStatus, err := store.LastStatus(user.ChatID, hw.Name)
 			if err != nil {
-				logger.Printf("Ошибка при преобразовании TelegramChatID в int64: %v", err)
-				return nil, err
+				logger.StoreError(user.ChatID, "status_read_error", err)
+				continue
+			}
+			if hw.Status == lastStatus {
+				continue
 			}
 
-			err = sendMessage(chatID, message)
+			message, err := parseStatus(hw)
 			if err != nil {
-				logger.Printf("Ошибка при отправке сообщения в Telegram: %v", err)
-			} else {
-				logger.Printf("Сообщение отправлено в Telegram: %s", message)
-				lastSentMessage = message // Обновление последнего отправленного сообщения
+				continue
 			}
-		}
-	} else {
-		logger.Println("Результат запроса к API: Нет новых статусов работ.")
-	}
 
-	return newHomeworks, nil
-}
+			if !muted {
+				text, keyboard, err := formatNotification(hw)
+				if err != nil {
+					continue
+				}
+				if _, err := sendRichMessage(user.ChatID, text, keyboard); err != nil {
+					logger.SendFailure(user.ChatID, err)
+					continue
+				}
+				logger.StatusChange(user.ChatID, hw.Name, hw.Status)
+				notificationsTotal.WithLabelValues(hw.Status).Inc()
+				if err := store.AppendHistory(user.ChatID, message); err != nil {
+					logger.StoreError(user.ChatID, "history_write_error", err)
+				}
+			}
 
-func main() {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Произошла ошибка:", r)
+			if err := store.SetLastStatus(user.ChatID, hw.Name, hw.Status); err != nil {
+				logger.StoreError(user.ChatID, "status_write_error", err)
+			}
 		}
-	}()
-
-	logger.Println("Бот начал работу")
+	}
 
-	// Запрос к API перед обработкой обновлений
-	go func() {
-		_, err := fetchAPIResponse()
-		if err != nil {
-			return
+	if currentDate, ok := response["current_date"].(float64); ok {
+		if err := store.SetCursor(user.ChatID, int64(currentDate)); err != nil {
+			logger.StoreError(user.ChatID, "cursor_write_error", err)
 		}
-	}()
+	}
 
-	ticker := time.NewTicker(RetryPeriod)
+	return newHomeworks, nil
+}
 
-	go func() {
-		for range ticker.C {
-			_, err := fetchAPIResponse()
-			if err != nil {
-				continue
-			}
-		}
-	}()
+// pollConcurrency ограничивает число пользователей, опрашиваемых одновременно,
+// чтобы один зависший или заблокированный API-токен не останавливал опрос
+// остальных на время backoff'а и circuit breaker'а.
+const pollConcurrency = 5
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+// pollUsers опрашивает API от имени каждого зарегистрированного пользователя,
+// используя пул воркеров размером pollConcurrency, так что медленный или
+// сломанный пользователь не блокирует уведомления для всех остальных.
+func pollUsers() {
+	start := time.Now()
+	defer func() { pollDuration.Observe(time.Since(start).Seconds()) }()
 
-	updates, err := bot.GetUpdatesChan(u)
+	users, err := store.Users()
 	if err != nil {
-		logger.Fatal(err)
+		logger.Error("не удалось получить список пользователей", "event", "store_error", "error", err.Error())
+		return
 	}
 
-	go handleUpdates(updates)
+	sem := make(chan struct{}, pollConcurrency)
+	var wg sync.WaitGroup
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
+	for _, user := range users {
+		user := user
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, _ = fetchAPIResponse(user)
+		}()
+	}
 
-	logger.Println("Бот остановлен")
-	ticker.Stop()
+	wg.Wait()
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleCallbackQuery обрабатывает нажатия на инлайн-кнопки уведомлений о статусе работы.
+func handleCallbackQuery(cq *tgbotapi.CallbackQuery) {
+	if cq.Message == nil {
+		return
+	}
+
+	action, id, ok := parseCallbackData(cq.Data)
+	if !ok {
+		return
+	}
+
+	payload, found := lookupCallbackPayload(id)
+	if !found {
+		logger.Warn("неизвестный id callback-кнопки", "event", "unknown_callback_id", "user_id", cq.Message.Chat.ID, "id", id)
+		return
+	}
+	homeworkName, lessonName := payload.HomeworkName, payload.LessonName
+
+	switch action {
+	case actionShowLesson:
+		answer := tgbotapi.NewCallback(cq.ID, fmt.Sprintf("Урок: %s", lessonName))
+		answer.ShowAlert = true
+		if _, err := bot.AnswerCallbackQuery(answer); err != nil {
+			logger.SendFailure(cq.Message.Chat.ID, err)
+		}
+		return
+	case actionHideComment:
+		header := strings.SplitN(cq.Message.Text, "\nКомментарий ревьюера:", 2)[0]
+		edit := tgbotapi.NewEditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, header)
+		if _, err := bot.Send(edit); err != nil {
+			logger.SendFailure(cq.Message.Chat.ID, err)
+		}
+	case actionMarkRead:
+		edit := tgbotapi.NewEditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, cq.Message.Text+"\n\n✅ Прочитано")
+		if _, err := bot.Send(edit); err != nil {
+			logger.SendFailure(cq.Message.Chat.ID, err)
+		}
+	default:
+		logger.Warn("неизвестное действие callback", "event", "unknown_callback", "user_id", cq.Message.Chat.ID, "homework_name", homeworkName, "action", action)
+		return
+	}
+
+	if _, err := bot.AnswerCallbackQuery(tgbotapi.NewCallback(cq.ID, "")); err != nil {
+		logger.SendFailure(cq.Message.Chat.ID, err)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+func handleStart(msg *tgbotapi.Message) {
+	sendMessage(msg.Chat.ID, "Привет! Я бот, который отслеживает статус проверки домашних работ.\n"+
+		"Зарегистрируйтесь командой /register <токен Практикума>, чтобы начать получать уведомления.\n"+
+		"Список команд: /help")
+}
+
+func handleHelp(msg *tgbotapi.Message) {
+	sendMessage(msg.Chat.ID, "Доступные команды:\n"+
+		"/register <токен> — зарегистрироваться и начать получать уведомления\n"+
+		"/unregister — удалить регистрацию и токен\n"+
+		"/status — проверить статус работ прямо сейчас\n"+
+		"/last — последнее отправленное уведомление\n"+
+		"/history — история последних уведомлений\n"+
+		"/mute — приостановить уведомления\n"+
+		"/subscribe — возобновить уведомления после /mute\n"+
+		"/settings — текущие настройки аккаунта")
+}
+
+func handleRegister(msg *tgbotapi.Message) {
+	token := strings.TrimSpace(msg.CommandArguments())
+	if token == "" {
+		sendMessage(msg.Chat.ID, "Укажите токен: /register <токен Практикума>")
+		return
+	}
+	if err := store.RegisterUser(msg.Chat.ID, token); err != nil {
+		logger.StoreError(msg.Chat.ID, "register_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось сохранить токен, попробуйте позже.")
+		return
+	}
+	// Новый токен может исправлять авторизацию, из-за которой ранее сработал
+	// CircuitBreaker — сбрасываем его, чтобы опрос возобновился сразу, а не
+	// после 24-часового authCooldown.
+	resetCircuitBreakerFor(msg.Chat.ID)
+	sendMessage(msg.Chat.ID, "Вы зарегистрированы и будете получать уведомления об изменении статуса работ.")
+}
+
+func handleUnregister(msg *tgbotapi.Message) {
+	if err := store.UnregisterUser(msg.Chat.ID); err != nil {
+		logger.StoreError(msg.Chat.ID, "unregister_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось отписать вас, попробуйте позже.")
+		return
+	}
+	sendMessage(msg.Chat.ID, "Вы отписаны от уведомлений.")
+}
+
+func handleStatus(msg *tgbotapi.Message) {
+	go func() {
+		token, _, err := store.UserToken(msg.Chat.ID)
+		if err != nil {
+			logger.StoreError(msg.Chat.ID, "token_read_error", err)
+			sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
+			return
+		}
+
+		response, err := getAPIAnswerWithRetry(msg.Chat.ID, token, time.Now().Unix()-3600)
+		if err != nil {
+			logger.APIError(msg.Chat.ID, 0, err)
+			sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
+			return
+		}
+
+		newHomeworks, err := checkResponse(response)
+		if err != nil {
+			logger.APIError(msg.Chat.ID, 0, err)
+			sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
+			return
+		}
+
+		if len(newHomeworks) == 0 {
+			sendMessage(msg.Chat.ID, "Нет новых статусов работ.")
+			return
+		}
+
+		message, err := parseStatus(newHomeworks[0])
+		if err != nil {
+			sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
+			return
+		}
+		sendMessage(msg.Chat.ID, message)
+	}()
+}
+
+func handleLast(msg *tgbotapi.Message) {
+	history, err := store.History(msg.Chat.ID)
+	if err != nil {
+		logger.StoreError(msg.Chat.ID, "history_read_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось получить последнее уведомление.")
+		return
+	}
+	if len(history) == 0 {
+		sendMessage(msg.Chat.ID, "Уведомлений пока не было.")
+		return
+	}
+	sendMessage(msg.Chat.ID, history[len(history)-1])
+}
+
+// telegramMessageLimit — максимальная длина текста сообщения в Telegram.
+const telegramMessageLimit = 4096
+
+func handleHistory(msg *tgbotapi.Message) {
+	history, err := store.History(msg.Chat.ID)
+	if err != nil {
+		logger.StoreError(msg.Chat.ID, "history_read_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось получить историю уведомлений.")
+		return
+	}
+	if len(history) == 0 {
+		sendMessage(msg.Chat.ID, "Уведомлений пока не было.")
+		return
+	}
+
+	for _, page := range paginateHistory(history, telegramMessageLimit) {
+		if err := sendMessage(msg.Chat.ID, page); err != nil {
+			logger.SendFailure(msg.Chat.ID, err)
+			return
+		}
+	}
+}
+
+// paginateHistory объединяет записи истории через разделитель в страницы не
+// длиннее limit символов, чтобы не упереться в ограничение Telegram на длину
+// сообщения (telegramMessageLimit). Отдельная запись длиннее limit
+// усекается, чтобы гарантированно уместиться в одну страницу.
+func paginateHistory(history []string, limit int) []string {
+	const separator = "\n---\n"
+
+	var pages []string
+	var current strings.Builder
+
+	for _, entry := range history {
+		if len(entry) > limit {
+			entry = truncateToBytes(entry, limit)
+		}
+
+		addition := entry
+		if current.Len() > 0 {
+			addition = separator + entry
+		}
+
+		if current.Len() > 0 && current.Len()+len(addition) > limit {
+			pages = append(pages, current.String())
+			current.Reset()
+			addition = entry
+		}
+
+		current.WriteString(addition)
+	}
+
+	if current.Len() > 0 {
+		pages = append(pages, current.String())
+	}
+
+	return pages
+}
+
+// truncateToBytes укорачивает s до не более limit байт, добавляя "…", и
+// режет по границе руны, чтобы не повредить многобайтовые символы кириллицы.
+func truncateToBytes(s string, limit int) string {
+	const ellipsis = "…"
+	budget := limit - len(ellipsis)
+	if budget <= 0 {
+		return ellipsis
+	}
+	for budget > 0 && !utf8.RuneStart(s[budget]) {
+		budget--
+	}
+	return s[:budget] + ellipsis
+}
+
+func handleSubscribe(msg *tgbotapi.Message) {
+	if err := store.Unmute(msg.Chat.ID); err != nil {
+		logger.StoreError(msg.Chat.ID, "unmute_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось возобновить уведомления, попробуйте позже.")
+		return
+	}
+	sendMessage(msg.Chat.ID, "Уведомления возобновлены.")
+}
+
+func handleMute(msg *tgbotapi.Message) {
+	if err := store.Mute(msg.Chat.ID); err != nil {
+		logger.StoreError(msg.Chat.ID, "mute_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось приостановить уведомления, попробуйте позже.")
+		return
+	}
+	sendMessage(msg.Chat.ID, "Уведомления приостановлены. Возобновить: /subscribe")
+}
+
+func handleSettings(msg *tgbotapi.Message) {
+	_, registered, err := store.UserToken(msg.Chat.ID)
+	if err != nil {
+		logger.StoreError(msg.Chat.ID, "settings_read_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось получить настройки.")
+		return
+	}
+	muted, err := store.IsMuted(msg.Chat.ID)
+	if err != nil {
+		logger.StoreError(msg.Chat.ID, "mute_read_error", err)
+		sendMessage(msg.Chat.ID, "Не удалось получить настройки.")
+		return
+	}
+
+	sendMessage(msg.Chat.ID, fmt.Sprintf("Chat ID: %d\nЗарегистрирован: %s\nУведомления: %s",
+		msg.Chat.ID, yesNo(registered), yesNo(!muted)))
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "да"
+	}
+	return "нет"
+}
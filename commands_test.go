@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPaginateHistoryFitsInOnePage(t *testing.T) {
+	history := []string{"a", "b", "c"}
+	pages := paginateHistory(history, telegramMessageLimit)
+	if len(pages) != 1 {
+		t.Fatalf("expected 1 page, got %d", len(pages))
+	}
+	if pages[0] != "a\n---\nb\n---\nc" {
+		t.Fatalf("unexpected page content: %q", pages[0])
+	}
+}
+
+func TestPaginateHistorySplitsOnLimit(t *testing.T) {
+	history := []string{strings.Repeat("x", 10), strings.Repeat("y", 10), strings.Repeat("z", 10)}
+	pages := paginateHistory(history, 15)
+	if len(pages) != 3 {
+		t.Fatalf("expected 3 pages, got %d: %v", len(pages), pages)
+	}
+	for _, page := range pages {
+		if len(page) > 15 {
+			t.Fatalf("page exceeds limit: %q (%d chars)", page, len(page))
+		}
+	}
+}
+
+func TestPaginateHistoryTruncatesOversizedEntry(t *testing.T) {
+	history := []string{strings.Repeat("a", 20)}
+	pages := paginateHistory(history, 10)
+	if len(pages) != 1 {
+		t.Fatalf("expected 1 page, got %d", len(pages))
+	}
+	if len(pages[0]) > 10 {
+		t.Fatalf("page exceeds limit: %q (%d chars)", pages[0], len(pages[0]))
+	}
+}
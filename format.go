@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+const (
+	actionShowLesson  = "show_lesson"
+	actionHideComment = "hide_comment"
+	actionMarkRead    = "mark_read"
+)
+
+// modeMarkdownV2 — значение parse_mode для MarkdownV2. Библиотека
+// github.com/go-telegram-bot-api/telegram-bot-api@v4.6.4 определяет только
+// tgbotapi.ModeMarkdown (устаревший Markdown) и tgbotapi.ModeHTML, но сам
+// Telegram Bot API принимает строку "MarkdownV2" как есть.
+const modeMarkdownV2 = "MarkdownV2"
+
+var markdownV2Escaper = strings.NewReplacer(
+	"_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+	"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-",
+	"=", "\\=", "|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+)
+
+// escapeMarkdownV2 экранирует зарезервированные символы Telegram MarkdownV2.
+func escapeMarkdownV2(s string) string {
+	return markdownV2Escaper.Replace(s)
+}
+
+var codeBlockEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// escapeCodeBlock экранирует символы, зарезервированные внутри блока ```кода```.
+func escapeCodeBlock(s string) string {
+	return codeBlockEscaper.Replace(s)
+}
+
+// callbackPayload — имя работы и название урока, стоящие за коротким
+// идентификатором в callback_data инлайн-кнопки.
+type callbackPayload struct {
+	HomeworkName string
+	LessonName   string
+}
+
+var (
+	callbackRegistryMu sync.RWMutex
+	callbackRegistry   = make(map[string]callbackPayload)
+)
+
+// callbackID возвращает короткий стабильный идентификатор для пары
+// (имя работы, название урока) и запоминает её в callbackRegistry, чтобы
+// handleCallbackQuery мог найти исходные значения по этому идентификатору.
+// Telegram ограничивает callback_data 64 байтами, а реальные имена работ и
+// уроков Практикума сами по себе легко превышают этот лимит, поэтому в
+// callback_data нельзя встраивать их напрямую.
+func callbackID(homeworkName, lessonName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(homeworkName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(lessonName))
+	id := strconv.FormatUint(uint64(h.Sum32()), 36)
+
+	callbackRegistryMu.Lock()
+	callbackRegistry[id] = callbackPayload{HomeworkName: homeworkName, LessonName: lessonName}
+	callbackRegistryMu.Unlock()
+
+	return id
+}
+
+// lookupCallbackPayload возвращает имя работы и название урока, сохранённые
+// под id функцией callbackID, если они ещё не были вытеснены.
+func lookupCallbackPayload(id string) (callbackPayload, bool) {
+	callbackRegistryMu.RLock()
+	defer callbackRegistryMu.RUnlock()
+	payload, ok := callbackRegistry[id]
+	return payload, ok
+}
+
+// callbackData кодирует данные инлайн-кнопки как "действие|id", где id —
+// короткий идентификатор, выданный callbackID.
+func callbackData(action, homeworkName, lessonName string) string {
+	return fmt.Sprintf("%s|%s", action, callbackID(homeworkName, lessonName))
+}
+
+// parseCallbackData разбирает данные инлайн-кнопки, закодированные callbackData.
+func parseCallbackData(data string) (action, id string, ok bool) {
+	parts := strings.SplitN(data, "|", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// formatNotification готовит текст уведомления в MarkdownV2 с комментарием
+// ревьюера в блоке кода и клавиатуру с быстрыми действиями.
+func formatNotification(homework Homework) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	verdict, ok := HomeworkVerdict[homework.Status]
+	if !ok {
+		return "", tgbotapi.InlineKeyboardMarkup{}, fmt.Errorf("неизвестный статус домашней работы: %s", homework.Status)
+	}
+
+	text := fmt.Sprintf("Изменился статус проверки работы *%s* для урока *%s*: %s",
+		escapeMarkdownV2(homework.Name), escapeMarkdownV2(homework.LessonName), escapeMarkdownV2(verdict))
+
+	if homework.ReviewerComment != "" {
+		text += fmt.Sprintf("\nКомментарий ревьюера:\n```\n%s\n```", escapeCodeBlock(homework.ReviewerComment))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Показать урок", callbackData(actionShowLesson, homework.Name, homework.LessonName)),
+			tgbotapi.NewInlineKeyboardButtonData("Скрыть комментарий", callbackData(actionHideComment, homework.Name, homework.LessonName)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Отметить прочитанным", callbackData(actionMarkRead, homework.Name, homework.LessonName)),
+		),
+	)
+
+	return text, keyboard, nil
+}
+
+// sendRichMessage отправляет сообщение в MarkdownV2 с инлайн-клавиатурой.
+func sendRichMessage(chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = modeMarkdownV2
+	msg.ReplyMarkup = keyboard
+	sent, err := bot.Send(msg)
+	if err != nil {
+		telegramSendFailuresTotal.Inc()
+	}
+	return sent, err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// telegramCallbackDataLimit — ограничение Telegram на длину callback_data инлайн-кнопки.
+const telegramCallbackDataLimit = 64
+
+func TestCallbackDataWithinTelegramLimit(t *testing.T) {
+	homeworkName := strings.Repeat("Итоговый проект спринта 10: ", 3) + "Веб-приложение на Go и PostgreSQL"
+	lessonName := strings.Repeat("Продвинутые возможности стандартной библиотеки Go ", 2)
+	if len(homeworkName)+len(lessonName) < 190 {
+		t.Fatalf("test fixture too short to exercise the bug: %d bytes", len(homeworkName)+len(lessonName))
+	}
+
+	for _, action := range []string{actionShowLesson, actionHideComment, actionMarkRead} {
+		data := callbackData(action, homeworkName, lessonName)
+		if len(data) > telegramCallbackDataLimit {
+			t.Fatalf("callbackData(%q, ...) = %q (%d bytes), exceeds Telegram's %d-byte limit",
+				action, data, len(data), telegramCallbackDataLimit)
+		}
+	}
+}
+
+func TestCallbackDataRoundTripsThroughRegistry(t *testing.T) {
+	const homeworkName = "Проект"
+	const lessonName = "Урок"
+
+	data := callbackData(actionMarkRead, homeworkName, lessonName)
+
+	action, id, ok := parseCallbackData(data)
+	if !ok {
+		t.Fatalf("parseCallbackData(%q) returned ok=false", data)
+	}
+	if action != actionMarkRead {
+		t.Fatalf("action = %q, want %q", action, actionMarkRead)
+	}
+
+	payload, found := lookupCallbackPayload(id)
+	if !found {
+		t.Fatalf("lookupCallbackPayload(%q) not found", id)
+	}
+	if payload.HomeworkName != homeworkName || payload.LessonName != lessonName {
+		t.Fatalf("lookupCallbackPayload(%q) = %+v, want {%q, %q}", id, payload, homeworkName, lessonName)
+	}
+}
+
+func TestParseCallbackDataRejectsMalformedInput(t *testing.T) {
+	if _, _, ok := parseCallbackData("no-separator-here"); ok {
+		t.Fatalf("parseCallbackData accepted data with no separator")
+	}
+}
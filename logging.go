@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Logger оборачивает slog.Logger типизированными хелперами для событий, часто
+// встречающихся в боте, чтобы их поля (event, user_id, homework_name, status,
+// api_latency_ms, http_code) были единообразными и пригодными для индексации
+// внешними системами сбора логов.
+type Logger struct {
+	*slog.Logger
+}
+
+// newLogger создаёт структурированный JSON-логгер, пишущий одновременно в
+// stdout и в файл path, который ротируется по размеру и возрасту через lumberjack.
+func newLogger(path string) *Logger {
+	rotator := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    10, // МБ
+		MaxBackups: 5,
+		MaxAge:     30, // дней
+		Compress:   true,
+	}
+
+	handler := slog.NewJSONHandler(io.MultiWriter(os.Stdout, rotator), nil)
+	return &Logger{Logger: slog.New(handler)}
+}
+
+// Command логирует получение команды от пользователя.
+func (l *Logger) Command(userID int64, command string) {
+	l.Info("получена команда", "event", "command", "user_id", userID, "command", command)
+}
+
+// APIRequest логирует успешный запрос к API Практикума с замером задержки.
+func (l *Logger) APIRequest(userID int64, httpCode int, latency time.Duration) {
+	l.Info("запрос к API выполнен", "event", "api_request", "user_id", userID, "http_code", httpCode, "api_latency_ms", latency.Milliseconds())
+}
+
+// APIError логирует неудачный запрос к API Практикума, включая сетевые ошибки
+// и ошибки декодирования ответа, для которых httpCode равен 0.
+func (l *Logger) APIError(userID int64, httpCode int, err error) {
+	l.Error("ошибка запроса к API", "event", "api_error", "user_id", userID, "http_code", httpCode, "error", err.Error())
+}
+
+// APIRetry логирует повторную попытку запроса к API после временной ошибки.
+func (l *Logger) APIRetry(userID int64, httpCode int, wait time.Duration, err error) {
+	attrs := []any{"event", "api_retry", "user_id", userID, "http_code", httpCode, "retry_in_ms", wait.Milliseconds()}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	l.Warn("повтор запроса к API", attrs...)
+}
+
+// StatusChange логирует обнаруженное изменение статуса домашней работы.
+func (l *Logger) StatusChange(userID int64, homeworkName, status string) {
+	l.Info("статус домашней работы изменился", "event", "status_change", "user_id", userID, "homework_name", homeworkName, "status", status)
+}
+
+// SendFailure логирует неудачную отправку сообщения в Telegram.
+func (l *Logger) SendFailure(userID int64, err error) {
+	l.Error("ошибка отправки сообщения в Telegram", "event", "send_failure", "user_id", userID, "error", err.Error())
+}
+
+// StoreError логирует ошибку чтения или записи в хранилище статусов.
+func (l *Logger) StoreError(userID int64, event string, err error) {
+	l.Error("ошибка хранилища", "event", event, "user_id", userID, "error", err.Error())
+}
+
+// Panic логирует панику, перехваченную при обработке команды.
+func (l *Logger) Panic(userID int64, command string, r any) {
+	l.Error("паника при обработке команды", "event", "panic", "user_id", userID, "command", command, "recovered", r)
+}
@@ -1,54 +1,45 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	"github.com/joho/godotenv"
 )
 
 const (
 	Endpoint        = "https://practicum.yandex.ru/api/user_api/homework_statuses/"
-	RetryPeriod     = 600 * time.Second
+	RetryPeriod     = 100 * time.Second
 	ApprovedStatus  = "approved"
 	ReviewingStatus = "reviewing"
 	RejectedStatus  = "rejected"
 )
 
 var (
-	PracticumToken  string
 	TelegramToken   string
-	TelegramChatID  string
 	HomeworkVerdict map[string]string
-	logger          *log.Logger
+	logger          *Logger
 	bot             *tgbotapi.BotAPI
+	store           *Store
 )
 
-type Homework struct {
-	Name   string `json:"homework_name"`
-	Status string `json:"status"`
-}
-
-func init() {
+// mustSetup загружает конфигурацию и поднимает внешние зависимости бота
+// (Telegram API, логгер, хранилище статусов). Вынесена из init() в явный
+// вызов из main(), чтобы `go test` не тянул сеть и файловую систему при
+// запуске любого теста пакета.
+func mustSetup() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatal("Ошибка при загрузке файла .env")
 	}
 
-	PracticumToken = os.Getenv("PRACTICUM_TOKEN")
 	TelegramToken = os.Getenv("TELEGRAM_TOKEN")
-	TelegramChatID = os.Getenv("TELEGRAM_CHAT_ID")
-
-	if PracticumToken == "" || TelegramToken == "" || TelegramChatID == "" {
+	if TelegramToken == "" {
 		log.Fatal("Отсутствуют переменные окружения")
 	}
 
@@ -58,179 +49,87 @@ func init() {
 		RejectedStatus:  "Работа проверена: у ревьюера есть замечания.",
 	}
 
-	logFile, err := os.OpenFile("bot.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatal("Ошибка при открытии файла журнала:", err)
+	if err := os.MkdirAll("logs", 0755); err != nil {
+		log.Fatal("Ошибка при создании директории журнала:", err)
 	}
 
-	logger = log.New(io.MultiWriter(os.Stdout, logFile), "BOT: ", log.Ldate|log.Ltime|log.Lshortfile)
-	logger.Println("Бот запущен")
+	logger = newLogger("logs/bot.log")
+	logger.Info("бот запущен", "event", "startup")
 
 	bot, err = tgbotapi.NewBotAPI(TelegramToken)
 	if err != nil {
 		log.Fatal("Ошибка при создании экземпляра бота:", err)
 	}
-	//bot.Debug = true
-	logger.Printf("Авторизован как @%s", bot.Self.UserName)
-}
-
-func sendMessage(chatID int64, message string) error {
-	msg := tgbotapi.NewMessage(chatID, message)
-	_, err := bot.Send(msg)
-	return err
-}
+	logger.Info("авторизован в Telegram", "event", "startup", "username", bot.Self.UserName)
 
-func getAPIAnswer(currentTimestamp int64) (map[string]interface{}, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("%s?from_date=%d", Endpoint, currentTimestamp)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+	badgerDir := os.Getenv("BADGER_DIR")
+	if badgerDir == "" {
+		badgerDir = "badger_data"
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", PracticumToken))
-	resp, err := client.Do(req)
+	store, err = OpenStore(badgerDir)
 	if err != nil {
-		logger.Printf("Ошибка при выполнении запроса к API: %v", err)
-		return nil, err
+		log.Fatal("Ошибка при открытии хранилища статусов:", err)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
+
+	// Для обратной совместимости: если заданы старые переменные окружения
+	// одного пользователя, регистрируем его автоматически.
+	if legacyToken, legacyChatID := os.Getenv("PRACTICUM_TOKEN"), os.Getenv("TELEGRAM_CHAT_ID"); legacyToken != "" && legacyChatID != "" {
+		chatID, err := strconv.ParseInt(legacyChatID, 10, 64)
 		if err != nil {
-			logger.Printf("Ошибка при закрытии тела ответа: %v", err)
+			log.Fatal("Ошибка при преобразовании TELEGRAM_CHAT_ID в int64:", err)
+		}
+		if err := store.RegisterUser(chatID, legacyToken); err != nil {
+			log.Fatal("Ошибка при автоматической регистрации пользователя:", err)
 		}
-	}(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		logger.Printf("Запрос к API завершился с кодом статуса: %d", resp.StatusCode)
-		return nil, fmt.Errorf("Запрос к API завершился с кодом статуса: %d", resp.StatusCode)
-	}
-	var data map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&data)
-	if err != nil {
-		logger.Printf("Ошибка при декодировании ответа от API: %v", err)
-		return nil, err
 	}
-	logger.Println("Успешно получен ответ от API")
-	return data, nil
 }
 
-func checkResponse(response map[string]interface{}) ([]Homework, error) {
-	homeworksJSON, ok := response["homeworks"].([]interface{})
-	if !ok {
-		return nil, errors.New("Неверный формат ответа: поле 'homeworks' не является списком")
-	}
-
-	homeworks := make([]Homework, len(homeworksJSON))
-	for i, hwJSON := range homeworksJSON {
-		hwMap, ok := hwJSON.(map[string]interface{})
-		if !ok {
-			return nil, errors.New("Неверный формат ответа: элемент 'homework' не является словарем")
-		}
+func main() {
+	mustSetup()
 
-		hwName, ok := hwMap["homework_name"].(string)
-		if !ok {
-			return nil, errors.New("Неверный формат ответа: поле 'homework_name' не является строкой")
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("паника в main", "event", "panic", "recovered", r)
 		}
+	}()
+	defer store.Close()
 
-		hwStatus, ok := hwMap["status"].(string)
-		if !ok {
-			return nil, errors.New("Неверный формат ответа: поле 'status' не является строкой")
-		}
+	logger.Info("бот начал работу", "event", "startup")
 
-		homeworks[i] = Homework{Name: hwName, Status: hwStatus}
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if metricsAddr == "" {
+		metricsAddr = ":9090"
 	}
+	startMetricsServer(metricsAddr)
 
-	return homeworks, nil
-}
-
-func parseStatus(homework Homework) (string, error) {
-	verdict, ok := HomeworkVerdict[homework.Status]
-	if !ok {
-		if homework.Status == "Нет новых статусов работ." {
-			logger.Println(homework.Status)
-			return homework.Status, nil
-		}
-		errMsg := fmt.Sprintf("Неизвестный статус домашней работы: %s", homework.Status)
-		logger.Printf("Ошибка при разборе статуса домашней работы: %s", errMsg)
-		return "", errors.New(errMsg)
-	}
-	return fmt.Sprintf(`Изменился статус проверки работы "%s": %s`, homework.Name, verdict), nil
-}
+	commandRouter = buildCommandRouter()
 
-func handleCommand(msg *tgbotapi.Message) {
-	switch msg.Command() {
-	case "start":
-		sendMessage(msg.Chat.ID, "Привет! Я бот, который отслеживает статус проверки домашних работ.")
-		logger.Printf("Получена команда /start от пользователя с ID %d\n", msg.From.ID)
-	case "status":
-		go func() {
-			currentTimestamp := time.Now().Unix()
-			response, err := getAPIAnswer(currentTimestamp)
-			if err != nil {
-				logger.Printf("Не удалось получить ответ от API: %v", err)
-				sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
-				return
-			}
-
-			currentTimestamp = int64(response["current_date"].(float64))
-			newHomeworks, err := checkResponse(response)
-			if err != nil {
-				logger.Printf("Неверный ответ от API: %v", err)
-				sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
-				return
-			}
-
-			if len(newHomeworks) > 0 {
-				currentReport := newHomeworks[0]
-				message, err := parseStatus(currentReport)
-				if err != nil {
-					sendMessage(msg.Chat.ID, "Не удалось получить статус домашних работ.")
-					return
-				}
-
-				sendMessage(msg.Chat.ID, message)
-				logger.Printf("Получена команда /status от пользователя с ID %d\n", msg.From.ID)
-				logger.Printf("Результат запроса к API: %s\n", message)
-			} else {
-				sendMessage(msg.Chat.ID, "Нет новых статусов работ.")
-				logger.Printf("Получена команда /status от пользователя с ID %d\n", msg.From.ID)
-				logger.Println("Результат запроса к API: Нет новых статусов работ.")
-			}
-		}()
-	}
-}
+	// Опрос API для всех зарегистрированных пользователей перед обработкой обновлений
+	go pollUsers()
 
-func handleUpdates(updates tgbotapi.UpdatesChannel) {
-	for update := range updates {
-		if update.Message != nil {
-			if update.Message.IsCommand() {
-				handleCommand(update.Message)
-			}
-		}
-	}
-}
+	ticker := time.NewTicker(RetryPeriod)
 
-func main() {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Произошла ошибка:", r)
+	go func() {
+		for range ticker.C {
+			pollUsers()
 		}
 	}()
 
-	logger.Println("Бот начал работу")
-
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates, err := bot.GetUpdatesChan(u)
 	if err != nil {
-		log.Fatal("Ошибка при получении канала обновлений:", err)
+		logger.Error("не удалось получить канал обновлений Telegram", "event", "startup", "error", err.Error())
+		os.Exit(1)
 	}
 
 	go handleUpdates(updates)
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	<-stop
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
 
-	logger.Println("Бот остановлен")
+	logger.Info("бот остановлен", "event", "shutdown")
+	ticker.Stop()
 }
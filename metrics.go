@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	apiRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yago_bot_api_requests_total",
+			Help: "Количество запросов к API Практикума по коду статуса ответа.",
+		},
+		[]string{"status_code"},
+	)
+
+	apiRequestDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "yago_bot_api_request_duration_seconds",
+			Help:    "Длительность запросов к API Практикума.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	pollDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "yago_bot_poll_duration_seconds",
+			Help:    "Длительность полного цикла опроса всех зарегистрированных пользователей.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	telegramSendFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "yago_bot_telegram_send_failures_total",
+			Help: "Количество неудачных попыток отправки сообщений в Telegram.",
+		},
+	)
+
+	notificationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yago_bot_notifications_total",
+			Help: "Количество доставленных уведомлений по типу вердикта.",
+		},
+		[]string{"verdict"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(apiRequestsTotal, apiRequestDuration, pollDuration, telegramSendFailuresTotal, notificationsTotal)
+}
+
+// startMetricsServer запускает HTTP-сервер с /metrics в формате Prometheus и
+// /healthz для проверки живости на указанном адресе.
+func startMetricsServer(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logger.Error("HTTP-сервер метрик остановлен", "event", "metrics_server_stopped", "error", err.Error())
+		}
+	}()
+}
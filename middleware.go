@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// withLogging логирует каждый вызов команды, заменяя разрозненные вызовы
+// логгера, которые раньше были разбросаны по каждому case в handleCommand.
+func withLogging(next HandlerFunc) HandlerFunc {
+	return func(msg *tgbotapi.Message) {
+		logger.Command(int64(msg.From.ID), msg.Command())
+		next(msg)
+	}
+}
+
+// withRecover перехватывает панику внутри обработчика команды, чтобы она не
+// уронила весь процесс бота.
+func withRecover(next HandlerFunc) HandlerFunc {
+	return func(msg *tgbotapi.Message) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Panic(int64(msg.From.ID), msg.Command(), r)
+			}
+		}()
+		next(msg)
+	}
+}
+
+// withRateLimit не даёт одному пользователю вызывать команды чаще, чем раз в interval.
+func withRateLimit(interval time.Duration) Middleware {
+	var mu sync.Mutex
+	lastCall := make(map[int64]time.Time)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *tgbotapi.Message) {
+			userID := int64(msg.From.ID)
+			mu.Lock()
+			last, seen := lastCall[userID]
+			now := time.Now()
+			if seen && now.Sub(last) < interval {
+				mu.Unlock()
+				sendMessage(msg.Chat.ID, "Слишком много команд подряд, подождите немного.")
+				return
+			}
+			lastCall[userID] = now
+			mu.Unlock()
+			next(msg)
+		}
+	}
+}
+
+// withAuth пропускает к обработчику только зарегистрированных пользователей.
+// Команды, перечисленные в exempt, доступны без регистрации.
+func withAuth(exempt ...string) Middleware {
+	exemptCommands := make(map[string]struct{}, len(exempt))
+	for _, cmd := range exempt {
+		exemptCommands[cmd] = struct{}{}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *tgbotapi.Message) {
+			if _, ok := exemptCommands[msg.Command()]; ok {
+				next(msg)
+				return
+			}
+
+			_, registered, err := store.UserToken(msg.Chat.ID)
+			if err != nil {
+				logger.StoreError(msg.Chat.ID, "auth_check_error", err)
+				sendMessage(msg.Chat.ID, "Не удалось проверить регистрацию, попробуйте позже.")
+				return
+			}
+			if !registered {
+				sendMessage(msg.Chat.ID, "Сначала зарегистрируйтесь: /register <токен Практикума>")
+				return
+			}
+			next(msg)
+		}
+	}
+}
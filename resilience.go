@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	backoffBase     = 1 * time.Second
+	backoffFactor   = 2.0
+	backoffCap      = 60 * time.Second
+	maxAttempts     = 5
+	circuitCooldown = 5 * time.Minute
+	authCooldown    = 24 * time.Hour
+)
+
+// ErrCircuitOpen возвращается, когда опрос API для пользователя временно
+// приостановлен после исчерпания попыток восстановления.
+var ErrCircuitOpen = errors.New("цепь отключена: опрос API временно приостановлен")
+
+// ErrAuthFailed возвращается, когда API отклонил токен пользователя как недействительный.
+var ErrAuthFailed = errors.New("неверный токен Практикума")
+
+// apiStatusError — ответ API с кодом статуса, отличным от 200 OK.
+type apiStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("запрос к API завершился с кодом статуса: %d", e.StatusCode)
+}
+
+// parseRetryAfter разбирает заголовок Retry-After в виде числа секунд или HTTP-даты.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := time.Parse(http.TimeFormat, header); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// CircuitBreaker приостанавливает опрос API на период cooldown после серии
+// неудачных попыток, напоминая о себе отправкой уведомления только один раз.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	openUntil time.Time
+	notified  bool
+}
+
+// Open сообщает, находится ли цепь в разомкнутом (приостановленном) состоянии.
+func (cb *CircuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return time.Now().Before(cb.openUntil)
+}
+
+// Trip размыкает цепь на cooldown и сообщает, было ли уведомление уже отправлено ранее.
+func (cb *CircuitBreaker) Trip(cooldown time.Duration) (alreadyNotified bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	alreadyNotified = cb.notified
+	cb.openUntil = time.Now().Add(cooldown)
+	cb.notified = true
+	return alreadyNotified
+}
+
+// Reset замыкает цепь обратно после успешного запроса.
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.openUntil = time.Time{}
+	cb.notified = false
+}
+
+var (
+	circuitBreakersMu sync.Mutex
+	circuitBreakers   = make(map[int64]*CircuitBreaker)
+)
+
+func circuitBreakerFor(chatID int64) *CircuitBreaker {
+	circuitBreakersMu.Lock()
+	defer circuitBreakersMu.Unlock()
+	cb, ok := circuitBreakers[chatID]
+	if !ok {
+		cb = &CircuitBreaker{}
+		circuitBreakers[chatID] = cb
+	}
+	return cb
+}
+
+// resetCircuitBreakerFor замыкает цепь пользователя chatID, если она была
+// разомкнута ранее. Вызывается при повторной регистрации токена, чтобы
+// authCooldown (24 часа) после невалидного токена не держал пользователя
+// заблокированным уже после того, как он его исправил через /register.
+func resetCircuitBreakerFor(chatID int64) {
+	circuitBreakersMu.Lock()
+	cb, ok := circuitBreakers[chatID]
+	circuitBreakersMu.Unlock()
+	if ok {
+		cb.Reset()
+	}
+}
+
+// backoffDelay возвращает задержку до следующей попытки: экспоненциальный рост
+// от backoffBase с коэффициентом backoffFactor, ограниченный backoffCap, со
+// случайным джиттером по всей длине интервала (full jitter).
+func backoffDelay(attempt int) time.Duration {
+	d := time.Duration(float64(backoffBase) * math.Pow(backoffFactor, float64(attempt)))
+	if d > backoffCap {
+		d = backoffCap
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// getAPIAnswerWithRetry запрашивает API от имени пользователя chatID, повторяя
+// запрос при временных ошибках (5xx, сетевые сбои, 429 с учётом Retry-After) с
+// экспоненциальной задержкой и джиттером. После исчерпания попыток приостанавливает
+// дальнейший опрос этого пользователя через CircuitBreaker. Ответы 401/403
+// считаются недействительным токеном и не повторяются.
+func getAPIAnswerWithRetry(chatID int64, token string, fromDate int64) (map[string]interface{}, error) {
+	cb := circuitBreakerFor(chatID)
+	if cb.Open() {
+		return nil, ErrCircuitOpen
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		data, err := getAPIAnswer(chatID, token, fromDate)
+		if err == nil {
+			cb.Reset()
+			return data, nil
+		}
+		lastErr = err
+
+		var statusErr *apiStatusError
+		if errors.As(err, &statusErr) {
+			switch {
+			case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+				if !cb.Trip(authCooldown) {
+					sendMessage(chatID, "Похоже, ваш токен Практикума недействителен. Зарегистрируйтесь заново: /register <токен>")
+				}
+				return nil, fmt.Errorf("%w: %v", ErrAuthFailed, err)
+			case statusErr.StatusCode == http.StatusTooManyRequests:
+				wait := statusErr.RetryAfter
+				if wait <= 0 {
+					wait = backoffDelay(attempt)
+				}
+				logger.APIRetry(chatID, statusErr.StatusCode, wait, nil)
+				time.Sleep(wait)
+				continue
+			case statusErr.StatusCode >= 500:
+				wait := backoffDelay(attempt)
+				logger.APIRetry(chatID, statusErr.StatusCode, wait, nil)
+				time.Sleep(wait)
+				continue
+			default:
+				return nil, err
+			}
+		}
+
+		wait := backoffDelay(attempt)
+		logger.APIRetry(chatID, 0, wait, err)
+		time.Sleep(wait)
+	}
+
+	if !cb.Trip(circuitCooldown) {
+		sendMessage(chatID, "API недоступен, опрос приостановлен на некоторое время.")
+	}
+	return nil, fmt.Errorf("исчерпаны попытки запроса к API: %w", lastErr)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelayRespectsCap(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 20; i++ {
+			d := backoffDelay(attempt)
+			if d < 0 {
+				t.Fatalf("attempt %d: backoffDelay returned negative duration %s", attempt, d)
+			}
+			if d > backoffCap {
+				t.Fatalf("attempt %d: backoffDelay returned %s, exceeds cap %s", attempt, d, backoffCap)
+			}
+		}
+	}
+}
+
+func TestBackoffDelayGrowsWithAttempt(t *testing.T) {
+	// full jitter means any single sample can be small, so compare the
+	// theoretical ceiling (backoffBase * backoffFactor^attempt, capped) across
+	// attempts rather than sampled values.
+	ceiling := func(attempt int) time.Duration {
+		d := backoffDelay(attempt)
+		for i := 0; i < 50 && d < backoffCap; i++ {
+			if next := backoffDelay(attempt); next > d {
+				d = next
+			}
+		}
+		return d
+	}
+
+	early := ceiling(0)
+	late := ceiling(3)
+	if late < early {
+		t.Fatalf("expected backoff ceiling to grow with attempt, got attempt0=%s attempt3=%s", early, late)
+	}
+}
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	d := parseRetryAfter("5")
+	if d != 5*time.Second {
+		t.Fatalf("parseRetryAfter(\"5\") = %s, want 5s", d)
+	}
+}
+
+func TestParseRetryAfterEmpty(t *testing.T) {
+	if d := parseRetryAfter(""); d != 0 {
+		t.Fatalf("parseRetryAfter(\"\") = %s, want 0", d)
+	}
+}
+
+func TestParseRetryAfterPastHTTPDate(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if d := parseRetryAfter(past); d != 0 {
+		t.Fatalf("parseRetryAfter(past date) = %s, want 0", d)
+	}
+}
+
+func TestParseRetryAfterFutureHTTPDate(t *testing.T) {
+	future := time.Now().Add(10 * time.Minute).UTC().Format(http.TimeFormat)
+	d := parseRetryAfter(future)
+	if d <= 0 || d > 10*time.Minute {
+		t.Fatalf("parseRetryAfter(future date) = %s, want (0, 10m]", d)
+	}
+}
+
+func TestParseRetryAfterGarbage(t *testing.T) {
+	if d := parseRetryAfter("not-a-duration"); d != 0 {
+		t.Fatalf("parseRetryAfter(garbage) = %s, want 0", d)
+	}
+}
+
+func TestResetCircuitBreakerForClosesOpenBreaker(t *testing.T) {
+	const chatID = 99001
+	cb := circuitBreakerFor(chatID)
+	cb.Trip(authCooldown)
+	if !cb.Open() {
+		t.Fatalf("circuit breaker should be open after Trip")
+	}
+
+	resetCircuitBreakerFor(chatID)
+
+	if cb.Open() {
+		t.Fatalf("circuit breaker should be closed after resetCircuitBreakerFor")
+	}
+}
+
+func TestResetCircuitBreakerForUnknownChatIsNoop(t *testing.T) {
+	resetCircuitBreakerFor(-1)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// HandlerFunc обрабатывает одну команду бота.
+type HandlerFunc func(msg *tgbotapi.Message)
+
+// Middleware оборачивает HandlerFunc дополнительным поведением.
+type Middleware func(HandlerFunc) HandlerFunc
+
+// Router — маршрутизатор команд с цепочкой middleware, вдохновлённый
+// обработчиками telebot v3.
+type Router struct {
+	middleware []Middleware
+	handlers   map[string]HandlerFunc
+}
+
+// NewRouter создаёт пустой роутер.
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+// Use добавляет middleware, применяемые к каждому обработчику в порядке регистрации.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// Register регистрирует обработчик команды cmd (без ведущего "/"), оборачивая
+// его в зарегистрированные middleware.
+func (r *Router) Register(cmd string, handler HandlerFunc) {
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	r.handlers[cmd] = handler
+}
+
+// Dispatch находит обработчик команды сообщения msg и выполняет его. Команды
+// без зарегистрированного обработчика молча игнорируются.
+func (r *Router) Dispatch(msg *tgbotapi.Message) {
+	handler, ok := r.handlers[msg.Command()]
+	if !ok {
+		return
+	}
+	handler(msg)
+}
+
+// commandRouter — маршрутизатор команд бота, собираемый в main перед запуском опроса обновлений.
+var commandRouter *Router
+
+// buildCommandRouter регистрирует все команды бота вместе со сквозной цепочкой middleware.
+func buildCommandRouter() *Router {
+	r := NewRouter()
+	r.Use(withRecover, withLogging, withRateLimit(time.Second), withAuth("start", "register", "help"))
+
+	r.Register("start", handleStart)
+	r.Register("help", handleHelp)
+	r.Register("register", handleRegister)
+	r.Register("unregister", handleUnregister)
+	r.Register("status", handleStatus)
+	r.Register("last", handleLast)
+	r.Register("history", handleHistory)
+	r.Register("subscribe", handleSubscribe)
+	r.Register("mute", handleMute)
+	r.Register("settings", handleSettings)
+
+	return r
+}
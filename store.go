@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+const (
+	userPrefix    = "user:"
+	statusPrefix  = "status:"
+	cursorPrefix  = "cursor:"
+	mutedPrefix   = "muted:"
+	historyPrefix = "history:"
+
+	// maxHistoryEntries ограничивает длину хранимой истории уведомлений на пользователя.
+	maxHistoryEntries = 20
+)
+
+// Store хранит зарегистрированных пользователей, последний известный статус
+// каждой их домашней работы и курсор опроса API, переживая перезапуски бота.
+type Store struct {
+	db *badger.DB
+}
+
+// User — зарегистрированный подписчик бота: Telegram-чат и его токен Практикума.
+type User struct {
+	ChatID int64
+	Token  string
+}
+
+func userKey(chatID int64) []byte {
+	return []byte(fmt.Sprintf("%s%d", userPrefix, chatID))
+}
+
+func statusKey(chatID int64, homeworkName string) []byte {
+	return []byte(fmt.Sprintf("%s%d:%s", statusPrefix, chatID, homeworkName))
+}
+
+func cursorKey(chatID int64) []byte {
+	return []byte(fmt.Sprintf("%s%d", cursorPrefix, chatID))
+}
+
+func mutedKey(chatID int64) []byte {
+	return []byte(fmt.Sprintf("%s%d", mutedPrefix, chatID))
+}
+
+func historyKey(chatID int64) []byte {
+	return []byte(fmt.Sprintf("%s%d", historyPrefix, chatID))
+}
+
+// OpenStore открывает (или создаёт) базу BadgerDB в каталоге dir.
+func OpenStore(dir string) (*Store, error) {
+	opts := badger.DefaultOptions(dir).WithLogger(nil)
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть хранилище: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close закрывает базу данных.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// RegisterUser сохраняет токен Практикума для пользователя chatID, перезаписывая
+// уже существующий, если пользователь регистрируется повторно.
+func (s *Store) RegisterUser(chatID int64, token string) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(userKey(chatID), []byte(token))
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось зарегистрировать пользователя %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// UnregisterUser удаляет пользователя chatID и забывает его токен.
+func (s *Store) UnregisterUser(chatID int64) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(userKey(chatID))
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось отписать пользователя %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// UserToken возвращает токен Практикума пользователя chatID и признак того,
+// что пользователь зарегистрирован.
+func (s *Store) UserToken(chatID int64) (string, bool, error) {
+	var token string
+	found := false
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(userKey(chatID))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			token = string(val)
+			return nil
+		})
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("не удалось прочитать токен пользователя %d: %w", chatID, err)
+	}
+	return token, found, nil
+}
+
+// Users возвращает всех зарегистрированных пользователей.
+func (s *Store) Users() ([]User, error) {
+	var users []User
+	err := s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(userPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			chatID, err := strconv.ParseInt(strings.TrimPrefix(string(item.Key()), userPrefix), 10, 64)
+			if err != nil {
+				return err
+			}
+			var token string
+			if err := item.Value(func(val []byte) error {
+				token = string(val)
+				return nil
+			}); err != nil {
+				return err
+			}
+			users = append(users, User{ChatID: chatID, Token: token})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать список пользователей: %w", err)
+	}
+	return users, nil
+}
+
+// LastStatus возвращает последний сохранённый статус работы homeworkName для
+// пользователя chatID или пустую строку, если статус ещё не встречался.
+func (s *Store) LastStatus(chatID int64, homeworkName string) (string, error) {
+	var status string
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(statusKey(chatID, homeworkName))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			status = string(val)
+			return nil
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("не удалось прочитать статус '%s': %w", homeworkName, err)
+	}
+	return status, nil
+}
+
+// SetLastStatus сохраняет текущий статус работы homeworkName для пользователя chatID.
+func (s *Store) SetLastStatus(chatID int64, homeworkName, status string) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(statusKey(chatID, homeworkName), []byte(status))
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить статус '%s': %w", homeworkName, err)
+	}
+	return nil
+}
+
+// Cursor возвращает последний сохранённый current_date для пользователя chatID
+// или 0, если опрос для него ещё ни разу не выполнялся.
+func (s *Store) Cursor(chatID int64) (int64, error) {
+	var cursor int64
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(cursorKey(chatID))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			cursor, err = strconv.ParseInt(string(val), 10, 64)
+			return err
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать курсор: %w", err)
+	}
+	return cursor, nil
+}
+
+// SetCursor сохраняет значение current_date, с которого нужно возобновлять опрос
+// API для пользователя chatID.
+func (s *Store) SetCursor(chatID int64, cursor int64) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(cursorKey(chatID), []byte(strconv.FormatInt(cursor, 10)))
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить курсор: %w", err)
+	}
+	return nil
+}
+
+// Mute приостанавливает уведомления для пользователя chatID, не затрагивая его регистрацию.
+func (s *Store) Mute(chatID int64) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(mutedKey(chatID), []byte("1"))
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось заглушить пользователя %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// Unmute возобновляет уведомления для пользователя chatID.
+func (s *Store) Unmute(chatID int64) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(mutedKey(chatID))
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось возобновить уведомления пользователя %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// IsMuted сообщает, приостановлены ли уведомления для пользователя chatID.
+func (s *Store) IsMuted(chatID int64) (bool, error) {
+	muted := false
+	err := s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(mutedKey(chatID))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		muted = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить заглушение пользователя %d: %w", chatID, err)
+	}
+	return muted, nil
+}
+
+// AppendHistory добавляет запись в историю уведомлений пользователя chatID,
+// сохраняя не более maxHistoryEntries последних записей.
+func (s *Store) AppendHistory(chatID int64, entry string) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		var entries []string
+		item, err := txn.Get(historyKey(chatID))
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err == nil {
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &entries)
+			}); err != nil {
+				return err
+			}
+		}
+
+		entries = append(entries, entry)
+		if len(entries) > maxHistoryEntries {
+			entries = entries[len(entries)-maxHistoryEntries:]
+		}
+
+		encoded, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		return txn.Set(historyKey(chatID), encoded)
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить историю пользователя %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// History возвращает записи истории уведомлений пользователя chatID от старых к новым.
+func (s *Store) History(chatID int64) ([]string, error) {
+	var entries []string
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(historyKey(chatID))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &entries)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать историю пользователя %d: %w", chatID, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestStoreCursorAndStatusSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+
+	const chatID = 42
+	if err := store.RegisterUser(chatID, "token-1"); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	if err := store.SetLastStatus(chatID, "hw1", ApprovedStatus); err != nil {
+		t.Fatalf("SetLastStatus: %v", err)
+	}
+	if err := store.SetCursor(chatID, 1234567890); err != nil {
+		t.Fatalf("SetCursor: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Reopen against the same directory, simulating a bot restart, and
+	// confirm nothing persisted above was lost or needs re-fetching.
+	reopened, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("reopen OpenStore: %v", err)
+	}
+	defer reopened.Close()
+
+	token, registered, err := reopened.UserToken(chatID)
+	if err != nil {
+		t.Fatalf("UserToken: %v", err)
+	}
+	if !registered || token != "token-1" {
+		t.Fatalf("UserToken = (%q, %v), want (\"token-1\", true)", token, registered)
+	}
+
+	status, err := reopened.LastStatus(chatID, "hw1")
+	if err != nil {
+		t.Fatalf("LastStatus: %v", err)
+	}
+	if status != ApprovedStatus {
+		t.Fatalf("LastStatus = %q, want %q", status, ApprovedStatus)
+	}
+
+	cursor, err := reopened.Cursor(chatID)
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+	if cursor != 1234567890 {
+		t.Fatalf("Cursor = %d, want 1234567890", cursor)
+	}
+}
+
+func TestStoreLastStatusUnknownHomeworkIsEmpty(t *testing.T) {
+	store, err := OpenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	status, err := store.LastStatus(1, "never-seen")
+	if err != nil {
+		t.Fatalf("LastStatus: %v", err)
+	}
+	if status != "" {
+		t.Fatalf("LastStatus for unseen homework = %q, want empty", status)
+	}
+}
+
+func TestStoreCursorDefaultsToZero(t *testing.T) {
+	store, err := OpenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	cursor, err := store.Cursor(1)
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+	if cursor != 0 {
+		t.Fatalf("Cursor for unseen user = %d, want 0", cursor)
+	}
+}
+
+func TestStoreAppendHistoryCapsEntries(t *testing.T) {
+	store, err := OpenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	const chatID = 7
+	for i := 0; i < maxHistoryEntries+5; i++ {
+		if err := store.AppendHistory(chatID, string(rune('a'+i%26))); err != nil {
+			t.Fatalf("AppendHistory: %v", err)
+		}
+	}
+
+	history, err := store.History(chatID)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != maxHistoryEntries {
+		t.Fatalf("History length = %d, want %d", len(history), maxHistoryEntries)
+	}
+}